@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// framer reads and writes records from a stream, using whatever framing the
+// user asked for on the command line. Next returns io.EOF once the
+// underlying reader is exhausted.
+type framer interface {
+	Next() (string, error)
+	WriteRecord(w io.Writer, s string) error
+}
+
+// newFramer builds the framer selected by the -0, -d, -json and
+// -length-prefixed flags, reading from r. At most one of those flags may be
+// set; the zero value is plain newline-delimited framing.
+func newFramer(r io.Reader) (framer, error) {
+	set := 0
+	for _, b := range []bool{*nul, *delim != "", *jsonMode, *lengthPrefixed} {
+		if b {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("at most one of -0, -d, -json, -length-prefixed may be given")
+	}
+
+	switch {
+	case *lengthPrefixed:
+		return newLengthPrefixedFramer(r), nil
+	case *jsonMode:
+		return newJSONFramer(r), nil
+	case *nul:
+		return newByteFramer(r, 0), nil
+	case *delim != "":
+		if len(*delim) != 1 {
+			return nil, fmt.Errorf("-d wants exactly one byte, got %q", *delim)
+		}
+		return newByteFramer(r, (*delim)[0]), nil
+	default:
+		return newLineFramer(r), nil
+	}
+}
+
+// lineFramer is the original newline-delimited framing: records are trimmed
+// of surrounding whitespace, matching historical rsampling behavior.
+type lineFramer struct {
+	br *bufio.Reader
+}
+
+func newLineFramer(r io.Reader) *lineFramer {
+	return &lineFramer{br: bufio.NewReader(r)}
+}
+
+func (f *lineFramer) Next() (string, error) {
+	line, err := f.br.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (f *lineFramer) WriteRecord(w io.Writer, s string) error {
+	_, err := fmt.Fprintln(w, s)
+	return err
+}
+
+// byteFramer splits records on an arbitrary single delimiter byte, such as
+// NUL (-0) or a user-supplied byte (-d). Unlike lineFramer it does not trim
+// the record, since the framing byte is the only thing that's guaranteed not
+// to appear inside a record.
+//
+// It works on raw bytes throughout rather than converting delim to a string
+// or rune: for a byte value like 0xff that isn't valid ASCII, string(delim)
+// or a "%c" verb would re-encode it as a two-byte UTF-8 sequence instead of
+// the single raw byte the user asked to split on.
+type byteFramer struct {
+	br    *bufio.Reader
+	delim byte
+}
+
+func newByteFramer(r io.Reader, delim byte) *byteFramer {
+	return &byteFramer{br: bufio.NewReader(r), delim: delim}
+}
+
+func (f *byteFramer) Next() (string, error) {
+	s, err := f.br.ReadString(f.delim)
+	if err != nil && s == "" {
+		return "", err
+	}
+	return string(bytes.TrimSuffix([]byte(s), []byte{f.delim})), nil
+}
+
+func (f *byteFramer) WriteRecord(w io.Writer, s string) error {
+	if _, err := io.WriteString(w, s); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{f.delim})
+	return err
+}
+
+// jsonFramer treats the input as a stream of JSON values, so that a
+// pretty-printed, multi-line object is sampled as a single record.
+type jsonFramer struct {
+	dec *json.Decoder
+}
+
+func newJSONFramer(r io.Reader) *jsonFramer {
+	return &jsonFramer{dec: json.NewDecoder(r)}
+}
+
+func (f *jsonFramer) Next() (string, error) {
+	var raw json.RawMessage
+	if err := f.dec.Decode(&raw); err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func (f *jsonFramer) WriteRecord(w io.Writer, s string) error {
+	_, err := fmt.Fprintln(w, s)
+	return err
+}
+
+// maxLengthPrefixedRecord caps the length lengthPrefixedFramer.Next will
+// allocate for a single record, so a corrupt or hostile 4-byte header (up to
+// ~4GiB) can't be used to exhaust memory.
+const maxLengthPrefixedRecord = 64 << 20 // 64MiB
+
+// lengthPrefixedFramer reads and writes records as a 4-byte big-endian
+// length followed by that many bytes of payload, for binary records that
+// can't be delimited by a sentinel byte.
+type lengthPrefixedFramer struct {
+	r io.Reader
+}
+
+func newLengthPrefixedFramer(r io.Reader) *lengthPrefixedFramer {
+	return &lengthPrefixedFramer{r: r}
+}
+
+func (f *lengthPrefixedFramer) Next() (string, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(f.r, lenBuf[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return "", err
+	}
+
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > maxLengthPrefixedRecord {
+		return "", fmt.Errorf("length-prefixed record of %d bytes exceeds the %d byte limit", n, maxLengthPrefixedRecord)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(f.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (f *lengthPrefixedFramer) WriteRecord(w io.Writer, s string) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(s)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// roundTrip reads every record out of f until EOF, then writes them back out
+// through f and checks the result matches wantOutput byte for byte.
+func roundTrip(t *testing.T, f framer, wantOutput string) []string {
+	t.Helper()
+
+	var records []string
+	for {
+		record, err := f.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	var out bytes.Buffer
+	for _, rec := range records {
+		if err := f.WriteRecord(&out, rec); err != nil {
+			t.Fatalf("WriteRecord: %v", err)
+		}
+	}
+	if out.String() != wantOutput {
+		t.Fatalf("round trip mismatch:\n got: %q\nwant: %q", out.String(), wantOutput)
+	}
+
+	return records
+}
+
+func TestLineFramerRoundTrip(t *testing.T) {
+	const in = "a\nbb\nccc\n"
+	records := roundTrip(t, newLineFramer(bytes.NewBufferString(in)), in)
+	assertRecords(t, records, []string{"a", "bb", "ccc"})
+}
+
+func TestByteFramerRoundTripASCII(t *testing.T) {
+	const in = "a\x00bb\x00ccc\x00"
+	records := roundTrip(t, newByteFramer(bytes.NewBufferString(in), 0), in)
+	assertRecords(t, records, []string{"a", "bb", "ccc"})
+}
+
+// TestByteFramerRoundTripHighByte exercises a delimiter above 0x7f, which
+// string(byte)/"%c" would mangle into a two-byte UTF-8 sequence instead of
+// treating it as a raw byte.
+func TestByteFramerRoundTripHighByte(t *testing.T) {
+	const in = "a\xffb\xffc\xff"
+	records := roundTrip(t, newByteFramer(bytes.NewBufferString(in), 0xff), in)
+	assertRecords(t, records, []string{"a", "b", "c"})
+}
+
+// TestJSONFramerRoundTrip checks that a pretty-printed, multi-line JSON
+// value is read as a single record (not split on its internal newlines),
+// keeping the decoder's raw bytes rather than re-compacting them.
+func TestJSONFramerRoundTrip(t *testing.T) {
+	const in = "{\"a\":1}\n{\n  \"b\": 2\n}\n"
+	records := roundTrip(t, newJSONFramer(bytes.NewBufferString(in)), in)
+	assertRecords(t, records, []string{`{"a":1}`, "{\n  \"b\": 2\n}"})
+}
+
+func TestLengthPrefixedFramerRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	f := newLengthPrefixedFramer(&buf)
+	want := []string{"a", "bb", "ccc"}
+	for _, rec := range want {
+		if err := f.WriteRecord(&buf, rec); err != nil {
+			t.Fatalf("WriteRecord: %v", err)
+		}
+	}
+
+	var got []string
+	for {
+		rec, err := f.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, rec)
+	}
+	assertRecords(t, got, want)
+}
+
+func TestLengthPrefixedFramerRejectsOversizedLength(t *testing.T) {
+	lenBuf := []byte{0xff, 0xff, 0xff, 0xff}
+	f := newLengthPrefixedFramer(bytes.NewReader(lenBuf))
+	if _, err := f.Next(); err == nil {
+		t.Fatal("Next succeeded on a 0xffffffff length prefix, want an error instead of a multi-GB allocation")
+	}
+}
+
+func assertRecords(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d records %q, want %d %q", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("record %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -24,17 +24,16 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"time"
+
+	"github.com/thoemmi/rsampling/reservoir"
 )
 
 const Version = "0.2.0"
@@ -42,72 +41,59 @@ const Version = "0.2.0"
 var (
 	size    = flag.Int("n", 16, "number of samples to obtain")
 	seed    = flag.Int64("r", int64(time.Now().Nanosecond()), "random seed")
+	jobs    = flag.Int("j", 1, "number of concurrent shards to sample with")
 	version = flag.Bool("version", false, "show program version")
-)
-
-// Reservoir for strings.
-type Reservoir struct {
-	counter int64
-	size    int
-	sample  []string
-}
-
-// NewReservoir creates a reservoir for 16 elements.
-func NewReservoir() *Reservoir {
-	return &Reservoir{size: 16}
-}
 
-// NewReservoirSize creates a reservoir a given number of elements.
-func NewReservoirSize(size int) *Reservoir {
-	return &Reservoir{size: size}
-}
+	nul            = flag.Bool("0", false, "use NUL as input/output record delimiter")
+	delim          = flag.String("d", "", "use the given byte as input/output record delimiter")
+	jsonMode       = flag.Bool("json", false, "treat each input as a JSON value")
+	lengthPrefixed = flag.Bool("length-prefixed", false, "use 4-byte big-endian length-prefixed records")
 
-// String print out the samples, each on one line.
-func (r *Reservoir) String() string {
-	return strings.Join(r.sample, "\n")
-}
+	snapshotInterval = flag.Duration("snapshot", 0, "periodically write the current sample to -snapshot-file")
+	snapshotSignal   = flag.String("snapshot-on-signal", "", "write the current sample to -snapshot-file when the given signal is received, e.g. SIGUSR1")
+	snapshotFile     = flag.String("snapshot-file", "rsampling.snapshot", "file written by -snapshot and -snapshot-on-signal")
+)
 
-// Sample returns the current slice.
-func (r *Reservoir) Sample() []string {
-	return r.sample
-}
+func main() {
+	flag.Parse()
+	if *version {
+		fmt.Println(Version)
+		os.Exit(0)
+	}
+	if *jobs > 1 && (*snapshotInterval > 0 || *snapshotSignal != "") {
+		log.Fatal("-snapshot and -snapshot-on-signal require -j 1")
+	}
 
-// P returns the ratio between sample size and number of elements seen. Used to
-// decide whether to store an element of not.
-func (r *Reservoir) P() float64 {
-	if r.counter < int64(r.size) {
-		return 0
+	f, err := newFramer(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return float64(r.size) / float64(r.counter)
-}
 
-// Add fills the reservoir. If the reservoir is filled, s might be discarded.
-func (r *Reservoir) Add(s string) {
-	if r.counter < int64(r.size) {
-		r.sample = append(r.sample, s)
+	var sample []string
+	if *jobs <= 1 {
+		sample = sampleSequential(f, *size, *seed)
 	} else {
-		if rand.Float64() < r.P() {
-			i := rand.Intn(r.size)
-			r.sample[i] = s
-		}
+		sample = sampleSharded(f, *size, *seed, *jobs)
 	}
-	r.counter++
+
+	printSample(f, sample)
 }
 
-func main() {
-	flag.Parse()
-	if *version {
-		fmt.Println(Version)
-		os.Exit(0)
+// sampleSequential reads records from f on the calling goroutine, dumping
+// the current sample to stdout whenever the process receives SIGINT, and
+// writing it to -snapshot-file as requested via -snapshot and
+// -snapshot-on-signal.
+func sampleSequential(f framer, size int, seed int64) []string {
+	rr := reservoir.NewWithSeed[string](size, seed)
+
+	if err := startSnapshots(rr, f); err != nil {
+		log.Fatal(err)
 	}
-	rand.Seed(*seed)
-	rr := NewReservoirSize(*size)
-	br := bufio.NewReader(os.Stdin)
 
 	once := sync.Once{}
 
 	for {
-		line, err := br.ReadString('\n')
+		record, err := f.Next()
 		if err == io.EOF {
 			break
 		}
@@ -116,22 +102,72 @@ func main() {
 		}
 
 		once.Do(func() {
+			// The goroutine below reads rr concurrently with this loop's
+			// own Add calls, so Add needs to start locking too.
+			rr.EnableConcurrentAccess()
+
 			c := make(chan os.Signal, 1)
 			signal.Notify(c, os.Interrupt)
 
 			go func() {
 				for range c {
-					for _, v := range rr.Sample() {
-						fmt.Println(v)
-					}
+					printSample(f, rr.Snapshot())
 				}
 			}()
 		})
 
-		rr.Add(strings.TrimSpace(line))
+		rr.Add(record)
+	}
+
+	return rr.Sample()
+}
+
+// sampleSharded splits records read from f across n goroutines, each
+// maintaining its own Reservoir with an independent *rand.Rand, and merges
+// them once f is exhausted. Framing (f.Next) still runs on the calling
+// goroutine, since it has to hand out records in order; what's dispatched to
+// shards is Reservoir.Add, at the cost of the SIGINT live-dump that
+// sampleSequential supports.
+func sampleSharded(f framer, size int, seed int64, n int) []string {
+	records := make(chan string, 4*n)
+	shards := make([]*reservoir.Reservoir[string], n)
+
+	var wg sync.WaitGroup
+	for i := range shards {
+		shards[i] = reservoir.NewWithSeed[string](size, seed+int64(i)+1)
+		wg.Add(1)
+		go func(rr *reservoir.Reservoir[string]) {
+			defer wg.Done()
+			for record := range records {
+				rr.Add(record)
+			}
+		}(shards[i])
+	}
+
+	for {
+		record, err := f.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		records <- record
+	}
+	close(records)
+	wg.Wait()
+
+	merged := shards[0]
+	for _, s := range shards[1:] {
+		merged.Merge(s)
 	}
+	return merged.Sample()
+}
 
-	for _, v := range rr.Sample() {
-		fmt.Println(v)
+func printSample(f framer, sample []string) {
+	for _, v := range sample {
+		if err := f.WriteRecord(os.Stdout, v); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
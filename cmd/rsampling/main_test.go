@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSampleSharded checks that every record handed to sampleSharded ends up
+// dispatched to exactly one shard and that the final merge doesn't drop or
+// duplicate anything: the result is the right size and every value is a
+// distinct record from the input.
+func TestSampleSharded(t *testing.T) {
+	const (
+		total = 5000
+		size  = 50
+	)
+
+	var sb strings.Builder
+	for i := 0; i < total; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+
+	f := newLineFramer(strings.NewReader(sb.String()))
+	sample := sampleSharded(f, size, 1, 4)
+
+	if got := len(sample); got != size {
+		t.Fatalf("len(sample) = %d, want %d", got, size)
+	}
+
+	seen := make(map[string]bool, size)
+	for _, v := range sample {
+		if seen[v] {
+			t.Fatalf("value %q appears more than once in the merged sample", v)
+		}
+		seen[v] = true
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n >= total {
+			t.Fatalf("sample contains %q, which is not one of the input records", v)
+		}
+	}
+}
+
+// TestSampleSequentialSIGINT drives sampleSequential's SIGINT-dump goroutine
+// while its main loop keeps calling Reservoir.Add, the scenario that needs
+// rr.EnableConcurrentAccess to have been called for both sides to be race
+// free. Run with `go test -race` to catch a regression.
+func TestSampleSequentialSIGINT(t *testing.T) {
+	// The SIGINT handler's goroutine keeps running (and writing to stdout)
+	// past the point sampleSequential returns, since nothing ever stops
+	// listening on the signal channel; redirecting os.Stdout around just
+	// this call would itself race with that goroutine, so the dump lines it
+	// prints are left to go to the test's own stdout instead.
+	const total = 200000
+	var sb strings.Builder
+	for i := 0; i < total; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	f := newLineFramer(strings.NewReader(sb.String()))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		self, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return
+		}
+		for i := 0; i < 20; i++ {
+			time.Sleep(100 * time.Microsecond)
+			self.Signal(os.Interrupt)
+		}
+	}()
+
+	sample := sampleSequential(f, 16, 1)
+	<-done
+
+	if got := len(sample); got != 16 {
+		t.Fatalf("len(sample) = %d, want 16", got)
+	}
+}
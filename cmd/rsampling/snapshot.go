@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/thoemmi/rsampling/reservoir"
+)
+
+// startSnapshots wires up the periodic (-snapshot) and signal-triggered
+// (-snapshot-on-signal) dumps of rr to -snapshot-file. It returns
+// immediately; the snapshots keep happening in the background goroutines it
+// starts until the process exits.
+func startSnapshots(rr *reservoir.Reservoir[string], f framer) error {
+	if *snapshotInterval > 0 || *snapshotSignal != "" {
+		// Once this function starts a goroutine that reads rr concurrently
+		// with the caller's Add loop, Add needs to start locking too.
+		rr.EnableConcurrentAccess()
+	}
+
+	if *snapshotInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(*snapshotInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := writeSnapshot(f, rr.Snapshot(), *snapshotFile); err != nil {
+					log.Println("snapshot:", err)
+				}
+			}
+		}()
+	}
+
+	if *snapshotSignal != "" {
+		sig, err := signalByName(*snapshotSignal)
+		if err != nil {
+			return err
+		}
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, sig)
+		go func() {
+			for range c {
+				if err := writeSnapshot(f, rr.Snapshot(), *snapshotFile); err != nil {
+					log.Println("snapshot:", err)
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// writeSnapshot writes sample to path atomically, by writing to a temporary
+// file in the same directory and renaming it into place, so a reader never
+// observes a partially written snapshot.
+func writeSnapshot(f framer, sample []string, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	for _, v := range sample {
+		if err := f.WriteRecord(tmp, v); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// signalByName maps the signal names accepted by -snapshot-on-signal to an
+// os.Signal.
+func signalByName(name string) (os.Signal, error) {
+	switch name {
+	case "SIGUSR1":
+		return syscall.SIGUSR1, nil
+	case "SIGUSR2":
+		return syscall.SIGUSR2, nil
+	case "SIGHUP":
+		return syscall.SIGHUP, nil
+	default:
+		return nil, fmt.Errorf("-snapshot-on-signal: unsupported signal %q", name)
+	}
+}
@@ -0,0 +1,35 @@
+package reservoir_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/thoemmi/rsampling/reservoir"
+)
+
+// TestEnableConcurrentAccess exercises Add and Snapshot from separate
+// goroutines after EnableConcurrentAccess, so `go test -race` catches a
+// regression in the locking that guards them.
+func TestEnableConcurrentAccess(t *testing.T) {
+	r := reservoir.NewWithSeed[int](16, 1)
+	r.EnableConcurrentAccess()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			r.Add(i)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		_ = r.Snapshot()
+		_ = r.Counter()
+	}
+	wg.Wait()
+
+	if got := len(r.Sample()); got != 16 {
+		t.Fatalf("len(Sample()) = %d, want 16", got)
+	}
+}
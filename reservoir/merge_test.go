@@ -0,0 +1,65 @@
+package reservoir_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/thoemmi/rsampling/reservoir"
+)
+
+func TestMergeCounterAndSize(t *testing.T) {
+	const size = 10
+
+	a := reservoir.NewWithSeed[int](size, 1)
+	for i := 0; i < 100; i++ {
+		a.Add(i)
+	}
+	b := reservoir.NewWithSeed[int](size, 2)
+	for i := 100; i < 300; i++ {
+		b.Add(i)
+	}
+
+	a.Merge(b)
+
+	if got := len(a.Sample()); got != size {
+		t.Fatalf("len(Sample()) = %d, want %d after merging two full reservoirs", got, size)
+	}
+	if got := a.Counter(); got != 300 {
+		t.Fatalf("Counter() = %d, want 300 (100 + 200 seen before merging)", got)
+	}
+}
+
+// TestMergeProportional is a Monte-Carlo check that Merge draws from each
+// side in proportion to the number of items it has seen, as documented.
+func TestMergeProportional(t *testing.T) {
+	const (
+		n1, n2 = 100, 300
+		size   = 20
+		trials = 500
+	)
+
+	fromB := 0
+	for trial := 0; trial < trials; trial++ {
+		a := reservoir.NewWithSeed[string](size, int64(2*trial))
+		for i := 0; i < n1; i++ {
+			a.Add("a")
+		}
+		b := reservoir.NewWithSeed[string](size, int64(2*trial+1))
+		for i := 0; i < n2; i++ {
+			b.Add("b")
+		}
+
+		a.Merge(b)
+		for _, v := range a.Sample() {
+			if v == "b" {
+				fromB++
+			}
+		}
+	}
+
+	want := float64(trials*size) * (float64(n2) / float64(n1+n2))
+	tolerance := want * 0.15
+	if math.Abs(float64(fromB)-want) > tolerance {
+		t.Fatalf("merge produced %d items from the n2=%d side across %d trials, want ~%.0f +/- %.0f", fromB, n2, trials, want, tolerance)
+	}
+}
@@ -0,0 +1,182 @@
+// Package reservoir implements reservoir sampling over streams of arbitrary
+// values.
+package reservoir
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reservoir samples a fixed number of values from a, potentially infinite,
+// stream. Add uses Vitter's Algorithm L, which only calls the RNG when an
+// item is actually going to replace a sample, instead of once per input
+// item.
+//
+// A Reservoir carries its own *rand.Rand instead of relying on the global
+// math/rand source, so that concurrent Reservoirs don't contend on a shared
+// lock and a given instance's behavior is reproducible from its seed alone.
+//
+// By default Add, Sample, Snapshot and Counter are only safe to call from a
+// single goroutine, and do no locking at all. Call EnableConcurrentAccess
+// before starting a second goroutine that will read the Reservoir (for
+// instance one that periodically snapshots it to a file) concurrently with
+// Add; this turns on a read-write lock shared by all of those methods. The
+// common case - a single goroutine calling Add in a hot loop with nothing
+// else observing the Reservoir - stays lock-free.
+type Reservoir[T any] struct {
+	mu         sync.RWMutex
+	concurrent atomic.Bool
+	rnd        *rand.Rand
+	counter    int64
+	size       int
+	sample     []T
+
+	// w and next track the state of Algorithm L: next is the counter value
+	// of the next item that will be considered for the reservoir, and w is
+	// the running "width" used to compute the gap until next.
+	w    float64
+	next int64
+}
+
+// New creates a Reservoir for the given number of elements, seeded from the
+// current time.
+func New[T any](size int) *Reservoir[T] {
+	return NewWithSeed[T](size, time.Now().UnixNano())
+}
+
+// NewWithSeed creates a Reservoir for the given number of elements, using the
+// given seed for its RNG. Two Reservoirs created with the same seed and fed
+// the same items produce the same sample.
+func NewWithSeed[T any](size int, seed int64) *Reservoir[T] {
+	return &Reservoir[T]{
+		size: size,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// EnableConcurrentAccess makes Add, Sample, Snapshot and Counter take a
+// read-write lock around their access to the Reservoir's state. Call it
+// before starting any goroutine that will call one of those methods
+// concurrently with Add; until it's called, the Reservoir assumes a single
+// goroutine owns it and does no locking.
+func (r *Reservoir[T]) EnableConcurrentAccess() {
+	r.concurrent.Store(true)
+}
+
+// Counter returns the number of items that have been offered to the
+// Reservoir via Add.
+func (r *Reservoir[T]) Counter() int64 {
+	if r.concurrent.Load() {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+	return r.counter
+}
+
+// Sample returns the current slice of sampled values. The returned slice is
+// shared with the Reservoir; it must not be mutated, and a concurrent Add
+// may mutate it in place, so callers that need a stable view while Add keeps
+// running should use Snapshot instead.
+func (r *Reservoir[T]) Sample() []T {
+	if r.concurrent.Load() {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+	return r.sample
+}
+
+// Snapshot returns a copy of the current sample, safe to keep and read after
+// Add has moved on, so a writer goroutine can persist it without racing with
+// the goroutine still calling Add.
+func (r *Reservoir[T]) Snapshot() []T {
+	if r.concurrent.Load() {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+	}
+	return append([]T(nil), r.sample...)
+}
+
+// Add fills the reservoir. If the reservoir is already full, v might be
+// discarded.
+func (r *Reservoir[T]) Add(v T) {
+	if r.concurrent.Load() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+
+	if r.size == 0 {
+		// A zero-size reservoir never samples anything; guard explicitly
+		// instead of relying on the fill branch below never running, since
+		// counter == next == 0 on the very first call would otherwise reach
+		// rnd.Intn(0) and panic.
+		r.counter++
+		return
+	}
+
+	if r.counter < int64(r.size) {
+		r.sample = append(r.sample, v)
+		r.counter++
+		if r.counter == int64(r.size) {
+			r.w = math.Exp(math.Log(r.rnd.Float64()) / float64(r.size))
+			r.next = r.counter + r.skip()
+		}
+		return
+	}
+
+	if r.counter == r.next {
+		r.sample[r.rnd.Intn(r.size)] = v
+		r.w *= math.Exp(math.Log(r.rnd.Float64()) / float64(r.size))
+		r.next = r.counter + 1 + r.skip()
+	}
+	r.counter++
+}
+
+// skip returns the number of items to discard before the next one is
+// considered for the reservoir, given the current width w.
+func (r *Reservoir[T]) skip() int64 {
+	return int64(math.Log(r.rnd.Float64()) / math.Log(1-r.w))
+}
+
+// Merge folds other's sample into r, as if both had been sampled from a
+// single combined stream. Each of r's output slots is drawn from r's own
+// sample or other's sample with probability proportional to the number of
+// items each has seen, without replacement, following the standard
+// partitioned-reservoir merge.
+func (r *Reservoir[T]) Merge(other *Reservoir[T]) {
+	if r.concurrent.Load() || other.concurrent.Load() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		other.mu.RLock()
+		defer other.mu.RUnlock()
+	}
+
+	n1, n2 := r.counter, other.counter
+	a := append([]T(nil), r.sample...)
+	b := append([]T(nil), other.sample...)
+
+	merged := make([]T, 0, len(a)+len(b))
+	for len(merged) < r.size && (len(a) > 0 || len(b) > 0) {
+		fromA := len(b) == 0
+		if len(a) > 0 && len(b) > 0 {
+			fromA = r.rnd.Int63n(n1+n2) < n1
+		}
+
+		if fromA {
+			i := r.rnd.Intn(len(a))
+			merged = append(merged, a[i])
+			a = append(a[:i], a[i+1:]...)
+			n1--
+		} else {
+			i := r.rnd.Intn(len(b))
+			merged = append(merged, b[i])
+			b = append(b[:i], b[i+1:]...)
+			n2--
+		}
+	}
+
+	r.sample = merged
+	r.counter += other.counter
+}
@@ -0,0 +1,80 @@
+package reservoir_test
+
+import (
+	"testing"
+
+	"github.com/thoemmi/rsampling/reservoir"
+)
+
+func TestAddFillsUpToSize(t *testing.T) {
+	r := reservoir.NewWithSeed[int](10, 1)
+	for i := 0; i < 4; i++ {
+		r.Add(i)
+	}
+	if got := len(r.Sample()); got != 4 {
+		t.Fatalf("len(Sample()) = %d, want 4 while stream is shorter than the reservoir", got)
+	}
+
+	for i := 4; i < 1000; i++ {
+		r.Add(i)
+	}
+	if got := len(r.Sample()); got != 10 {
+		t.Fatalf("len(Sample()) = %d, want 10 once the stream exceeds the reservoir size", got)
+	}
+	if got := r.Counter(); got != 1000 {
+		t.Fatalf("Counter() = %d, want 1000", got)
+	}
+}
+
+// TestAddZeroSize is a regression test for a zero-size Reservoir, which must
+// stay empty instead of panicking in rnd.Intn(0).
+func TestAddZeroSize(t *testing.T) {
+	r := reservoir.NewWithSeed[string](0, 1)
+	r.Add("a")
+	r.Add("b")
+	r.Add("c")
+
+	if got := len(r.Sample()); got != 0 {
+		t.Fatalf("len(Sample()) = %d, want 0 for a zero-size reservoir", got)
+	}
+	if got := r.Counter(); got != 3 {
+		t.Fatalf("Counter() = %d, want 3", got)
+	}
+}
+
+// TestAddUniformity is a Monte-Carlo check that Algorithm L selects every
+// item with roughly equal probability. It uses a chi-square
+// goodness-of-fit statistic over all n items rather than a per-item bound,
+// since checking 1000 per-item bounds independently would produce a false
+// positive from chance alone on almost every run.
+func TestAddUniformity(t *testing.T) {
+	const (
+		n      = 1000
+		size   = 50
+		trials = 2000
+		// critical value for a chi-square test with n-1 = 999 degrees of
+		// freedom at p ~ 3e-10; true uniform sampling lands far below this.
+		chi2Critical = 1300.0
+	)
+
+	counts := make([]int, n)
+	for trial := 0; trial < trials; trial++ {
+		r := reservoir.NewWithSeed[int](size, int64(trial))
+		for i := 0; i < n; i++ {
+			r.Add(i)
+		}
+		for _, v := range r.Sample() {
+			counts[v]++
+		}
+	}
+
+	want := float64(trials*size) / float64(n)
+	chi2 := 0.0
+	for _, c := range counts {
+		d := float64(c) - want
+		chi2 += d * d / want
+	}
+	if chi2 > chi2Critical {
+		t.Fatalf("chi-square statistic %.1f exceeds %.1f, sample looks biased (want ~%.1f selections per item)", chi2, chi2Critical, want)
+	}
+}
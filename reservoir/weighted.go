@@ -0,0 +1,81 @@
+package reservoir
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// weightedItem is one entry in a WeightedReservoir's min-heap, keyed by
+// u^(1/weight) as in Efraimidis-Spirakis weighted reservoir sampling (A-Res).
+type weightedItem[T any] struct {
+	key   float64
+	value T
+}
+
+// weightedHeap is a min-heap of weightedItem ordered by key, so the smallest
+// key - the first candidate to evict - is always at the root.
+type weightedHeap[T any] []weightedItem[T]
+
+func (h weightedHeap[T]) Len() int           { return len(h) }
+func (h weightedHeap[T]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h weightedHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *weightedHeap[T]) Push(x interface{}) { *h = append(*h, x.(weightedItem[T])) }
+func (h *weightedHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// WeightedReservoir samples values with probability proportional to a
+// per-item weight, using the A-Res algorithm: every item gets a key
+// u^(1/weight) for u ~ Uniform(0,1), and the reservoir keeps the k items with
+// the largest keys.
+type WeightedReservoir[T any] struct {
+	rnd  *rand.Rand
+	size int
+	heap weightedHeap[T]
+}
+
+// NewWeighted creates a WeightedReservoir for the given number of elements,
+// seeded from the current time.
+func NewWeighted[T any](size int) *WeightedReservoir[T] {
+	return NewWeightedWithSeed[T](size, time.Now().UnixNano())
+}
+
+// NewWeightedWithSeed creates a WeightedReservoir for the given number of
+// elements, using the given seed for its RNG.
+func NewWeightedWithSeed[T any](size int, seed int64) *WeightedReservoir[T] {
+	return &WeightedReservoir[T]{
+		size: size,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Sample returns the current slice of sampled values, in no particular
+// order.
+func (r *WeightedReservoir[T]) Sample() []T {
+	out := make([]T, len(r.heap))
+	for i, it := range r.heap {
+		out[i] = it.value
+	}
+	return out
+}
+
+// Add offers v to the reservoir with the given weight. Items with a higher
+// weight are more likely to end up in the final sample.
+func (r *WeightedReservoir[T]) Add(v T, weight float64) {
+	key := math.Pow(r.rnd.Float64(), 1/weight)
+	if r.heap.Len() < r.size {
+		heap.Push(&r.heap, weightedItem[T]{key: key, value: v})
+		return
+	}
+	if key > r.heap[0].key {
+		heap.Pop(&r.heap)
+		heap.Push(&r.heap, weightedItem[T]{key: key, value: v})
+	}
+}
@@ -0,0 +1,37 @@
+package reservoir_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/thoemmi/rsampling/reservoir"
+)
+
+// TestWeightedAddBias checks that A-Res actually favors heavier items,
+// rather than degenerating into a uniform sample.
+func TestWeightedAddBias(t *testing.T) {
+	const (
+		size   = 10
+		filler = 19
+		trials = 500
+	)
+
+	heavySelected := 0
+	for trial := 0; trial < trials; trial++ {
+		r := reservoir.NewWeightedWithSeed[string](size, int64(trial))
+		for i := 0; i < filler; i++ {
+			r.Add(fmt.Sprintf("filler%d", i), 1)
+		}
+		r.Add("heavy", 50)
+
+		for _, v := range r.Sample() {
+			if v == "heavy" {
+				heavySelected++
+			}
+		}
+	}
+
+	if heavySelected < trials*8/10 {
+		t.Fatalf("heavy item (weight 50 among %d weight-1 items) was only selected %d/%d times, want it reliably included", filler, heavySelected, trials)
+	}
+}